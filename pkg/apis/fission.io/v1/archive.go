@@ -0,0 +1,56 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+// ArchiveType specifies how an Archive's contents are made available: either
+// inline as a literal byte blob, or fetched from a URL.
+type ArchiveType string
+
+const (
+	ArchiveTypeLiteral ArchiveType = "literal"
+	ArchiveTypeUrl     ArchiveType = "url"
+)
+
+// ChecksumType identifies the algorithm used to compute a Checksum.Sum.
+type ChecksumType string
+
+const (
+	ChecksumTypeSHA256 ChecksumType = "sha256"
+)
+
+// Checksum is a content digest used to verify an Archive's integrity and to
+// deduplicate archives with identical contents.
+type Checksum struct {
+	Type ChecksumType `json:"type,omitempty"`
+	Sum  string       `json:"sum,omitempty"`
+}
+
+// Archive describes the contents of a package: either inline, or fetched
+// from a URL, along with a Checksum used to verify and deduplicate it.
+type Archive struct {
+	Type ArchiveType `json:"type,omitempty"`
+
+	// Literal contents of the archive, if small enough to be stored inline.
+	Literal []byte `json:"literal,omitempty"`
+
+	// URL points at the actual archive contents.
+	URL string `json:"url,omitempty"`
+
+	// Checksum of the archive contents, used for verification and
+	// deduplication on upload.
+	Checksum Checksum `json:"checksum,omitempty"`
+}
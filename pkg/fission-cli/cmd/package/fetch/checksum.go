@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// verifyChecksum hashes every file under dir (sorted by relative path, so
+// the result doesn't depend on the fetcher's traversal order) and checks it
+// against want, which must be of the form "sha256:<hex>".
+func verifyChecksum(dir, want string) error {
+	parts := strings.SplitN(want, ":", 2)
+	if len(parts) != 2 || parts[0] != "sha256" {
+		return fmt.Errorf("unsupported checksum format %q, expected \"sha256:<hex>\"", want)
+	}
+	hex := parts[1]
+
+	got, err := hashDir(dir)
+	if err != nil {
+		return err
+	}
+	if got != hex {
+		return fmt.Errorf("checksum mismatch: want sha256:%s, got sha256:%s", hex, got)
+	}
+	return nil
+}
+
+func hashDir(dir string) (string, error) {
+	var rels []string
+	if err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rels = append(rels, rel)
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	sort.Strings(rels)
+
+	h := sha256.New()
+	for _, rel := range rels {
+		f, err := os.Open(filepath.Join(dir, rel))
+		if err != nil {
+			return "", err
+		}
+		entry := sha256.New()
+		_, err = io.Copy(entry, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%x  %s\n", entry.Sum(nil), filepath.ToSlash(rel))
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fetch materializes go-getter-style source strings (git::, s3::,
+// gcs::, file::) into a local directory, so that CreateArchive can feed the
+// result into the normal archiving path as if it had always been a local
+// glob. Each scheme has its own Getter; Fetch picks the right one from the
+// source string's "<scheme>::" prefix.
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Source is a parsed go-getter-style source string, e.g.
+// "git::https://github.com/org/repo//subdir?ref=v1.2.3&checksum=sha256:abc".
+type Source struct {
+	Raw string
+
+	// Scheme is the part before "::", e.g. "git", "s3", "gcs", "file". Empty
+	// for plain http(s) URLs, which are left to the existing download path.
+	Scheme string
+
+	// Location is the URL/path after the scheme prefix, with any "//subdir"
+	// suffix and "checksum" query parameter already split off.
+	Location string
+
+	// Subdir, if set, is the subtree of the fetched content to use, taken
+	// from a "//subdir" suffix on Location.
+	Subdir string
+
+	// Checksum, if set, is the "sha256:<hex>" the fetched content must
+	// match.
+	Checksum string
+}
+
+var knownSchemes = map[string]Getter{
+	"git":  gitGetter{},
+	"s3":   s3Getter{},
+	"gcs":  gcsGetter{},
+	"file": fileGetter{},
+}
+
+// Getter materializes a Source's content into dest, an already-created
+// empty directory.
+type Getter interface {
+	Get(ctx context.Context, src *Source, dest string) error
+}
+
+// IsRemoteSource reports whether raw is a go-getter-style source this
+// package knows how to fetch, i.e. it has one of the "<scheme>::" prefixes
+// registered in knownSchemes.
+func IsRemoteSource(raw string) bool {
+	scheme, _, ok := splitScheme(raw)
+	if !ok {
+		return false
+	}
+	_, known := knownSchemes[scheme]
+	return known
+}
+
+// Fetch materializes raw into a freshly created temp directory and returns
+// its path. If raw carries a "?checksum=sha256:..." parameter, the fetched
+// content is hashed and verified to match before Fetch returns.
+func Fetch(ctx context.Context, raw string) (string, error) {
+	src, err := ParseSource(raw)
+	if err != nil {
+		return "", err
+	}
+
+	getter, ok := knownSchemes[src.Scheme]
+	if !ok {
+		return "", fmt.Errorf("unsupported source scheme %q", src.Scheme)
+	}
+
+	dest, err := ioutil.TempDir("", "fission-fetch-")
+	if err != nil {
+		return "", errors.Wrap(err, "creating temp dir for fetch")
+	}
+
+	if err := getter.Get(ctx, src, dest); err != nil {
+		return "", errors.Wrapf(err, "fetching %q", raw)
+	}
+
+	if src.Checksum != "" {
+		if err := verifyChecksum(dest, src.Checksum); err != nil {
+			return "", errors.Wrapf(err, "verifying checksum of %q", raw)
+		}
+	}
+
+	if src.Subdir != "" {
+		return dest + "/" + src.Subdir, nil
+	}
+	return dest, nil
+}
+
+// ParseSource splits a go-getter-style source string into its scheme,
+// location, optional "//subdir" suffix and optional "checksum" parameter.
+func ParseSource(raw string) (*Source, error) {
+	scheme, rest, ok := splitScheme(raw)
+	if !ok {
+		return nil, fmt.Errorf("%q has no recognized \"<scheme>::\" prefix", raw)
+	}
+
+	// The query string trails the whole "location//subdir" expression, so
+	// it has to be split off before splitSubdir runs; otherwise a query
+	// following a "//subdir" suffix ends up glued onto the subdir instead
+	// of being parsed.
+	rest, rawQuery := splitQuery(rest)
+	location, subdir := splitSubdir(rest)
+
+	checksum := ""
+	if rawQuery != "" {
+		q, err := url.ParseQuery(rawQuery)
+		if err == nil {
+			checksum = q.Get("checksum")
+			q.Del("checksum")
+			if remaining := q.Encode(); remaining != "" {
+				location += "?" + remaining
+			}
+		}
+	}
+
+	return &Source{
+		Raw:      raw,
+		Scheme:   scheme,
+		Location: location,
+		Subdir:   subdir,
+		Checksum: checksum,
+	}, nil
+}
+
+// splitQuery splits a trailing "?..." query string off s.
+func splitQuery(s string) (rest, query string) {
+	if i := strings.Index(s, "?"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+func splitScheme(raw string) (scheme, rest string, ok bool) {
+	i := strings.Index(raw, "::")
+	if i < 0 {
+		return "", raw, false
+	}
+	return raw[:i], raw[i+2:], true
+}
+
+// splitSubdir splits a "//subdir" suffix off location, as go-getter does,
+// e.g. "https://github.com/org/repo//cmd/foo" -> ("https://github.com/org/repo", "cmd/foo").
+func splitSubdir(location string) (string, string) {
+	i := strings.Index(location, "//")
+	if i < 0 {
+		return location, ""
+	}
+	// don't split the "//" of "https://"
+	if j := strings.Index(location, "://"); j >= 0 && i == j+1 {
+		rest := location[j+3:]
+		if k := strings.Index(rest, "//"); k >= 0 {
+			return location[:j+3+k], rest[k+2:]
+		}
+		return location, ""
+	}
+	return location[:i], location[i+2:]
+}
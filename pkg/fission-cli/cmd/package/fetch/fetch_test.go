@@ -0,0 +1,83 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import "testing"
+
+func TestIsRemoteSource(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want bool
+	}{
+		{"git::https://github.com/org/repo", true},
+		{"s3::https://s3.amazonaws.com/bucket/key", true},
+		{"gcs::gs://bucket/key", true},
+		{"file::/tmp/foo", true},
+		{"https://example.com/foo.zip", false},
+		{"./local/path", false},
+		{"bogus::https://example.com/x", false},
+	}
+	for _, c := range cases {
+		if got := IsRemoteSource(c.raw); got != c.want {
+			t.Errorf("IsRemoteSource(%q) = %v, want %v", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	src, err := ParseSource("git::https://github.com/org/repo//cmd/foo?ref=v1.2.3&checksum=sha256:abc123")
+	if err != nil {
+		t.Fatalf("ParseSource: %v", err)
+	}
+
+	if src.Scheme != "git" {
+		t.Errorf("Scheme = %q, want %q", src.Scheme, "git")
+	}
+	if src.Subdir != "cmd/foo" {
+		t.Errorf("Subdir = %q, want %q", src.Subdir, "cmd/foo")
+	}
+	if src.Checksum != "sha256:abc123" {
+		t.Errorf("Checksum = %q, want %q", src.Checksum, "sha256:abc123")
+	}
+	if src.Location != "https://github.com/org/repo?ref=v1.2.3" {
+		t.Errorf("Location = %q, want %q", src.Location, "https://github.com/org/repo?ref=v1.2.3")
+	}
+}
+
+func TestParseSourceRejectsMissingScheme(t *testing.T) {
+	if _, err := ParseSource("https://example.com/foo.zip"); err == nil {
+		t.Fatal("expected an error for a source with no \"<scheme>::\" prefix")
+	}
+}
+
+func TestSplitSubdir(t *testing.T) {
+	cases := []struct {
+		location   string
+		wantLoc    string
+		wantSubdir string
+	}{
+		{"https://github.com/org/repo//cmd/foo", "https://github.com/org/repo", "cmd/foo"},
+		{"https://github.com/org/repo", "https://github.com/org/repo", ""},
+		{"/local/path//sub/dir", "/local/path", "sub/dir"},
+	}
+	for _, c := range cases {
+		loc, subdir := splitSubdir(c.location)
+		if loc != c.wantLoc || subdir != c.wantSubdir {
+			t.Errorf("splitSubdir(%q) = (%q, %q), want (%q, %q)", c.location, loc, subdir, c.wantLoc, c.wantSubdir)
+		}
+	}
+}
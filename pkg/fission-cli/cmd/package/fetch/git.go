@@ -0,0 +1,53 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fetch
+
+import (
+	"context"
+	"net/url"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// gitGetter fetches a "git::<repo-url>[?ref=<ref>]" source by shelling out
+// to the system git binary, the same approach go-getter itself uses.
+type gitGetter struct{}
+
+func (gitGetter) Get(ctx context.Context, src *Source, dest string) error {
+	u, err := url.Parse(src.Location)
+	if err != nil {
+		return errors.Wrapf(err, "parsing git source %q", src.Location)
+	}
+	ref := u.Query().Get("ref")
+	u.RawQuery = ""
+
+	cloneArgs := []string{"clone", "--depth", "1"}
+	if ref != "" {
+		cloneArgs = append(cloneArgs, "--branch", ref)
+	}
+	// "--" stops git from interpreting a malicious url (e.g. one starting
+	// with "--upload-pack=") as another option instead of the repository
+	// to clone.
+	cloneArgs = append(cloneArgs, "--", u.String(), dest)
+
+	if out, err := exec.CommandContext(ctx, "git", cloneArgs...).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "git clone failed: %s", out)
+	}
+
+	return nil
+}
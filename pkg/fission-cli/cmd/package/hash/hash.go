@@ -0,0 +1,77 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package hash computes deterministic content hashes for package archives,
+// using the same "h1:" scheme as golang.org/x/mod/sumdb/dirhash: every entry
+// contributes a line "<sha256 of entry contents> <mode>  <name>\n", sorted
+// by name, into an outer SHA-256 that is then base64-encoded. Two archives
+// built from the same contents and permissions always hash to the same
+// value, regardless of when or where they were built.
+package hash
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// HashZip computes the "h1:" content hash of the zip archive at path.
+func HashZip(path string) (string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("opening zip archive: %w", err)
+	}
+	defer r.Close()
+
+	files := make([]*zip.File, len(r.File))
+	copy(files, r.File)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	h := sha256.New()
+	for _, zf := range files {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		sum, err := hashZipEntry(zf)
+		if err != nil {
+			return "", fmt.Errorf("hashing entry %q: %w", zf.Name, err)
+		}
+		fmt.Fprintf(h, "%x %o  %s\n", sum, zf.Mode().Perm(), zf.Name)
+	}
+
+	return encode(h.Sum(nil)), nil
+}
+
+func hashZipEntry(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func encode(sum []byte) string {
+	return "h1:" + base64.StdEncoding.EncodeToString(sum)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hash
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("creating %v: %v", path, err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("adding %v to zip: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("writing %v to zip: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("closing zip: %v", err)
+	}
+}
+
+func TestHashZipIsDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"b.txt":     "second",
+		"a.txt":     "first",
+		"dir/c.txt": "third",
+	}
+
+	p1 := filepath.Join(dir, "one.zip")
+	p2 := filepath.Join(dir, "two.zip")
+	writeTestZip(t, p1, files)
+	writeTestZip(t, p2, files)
+
+	sum1, err := HashZip(p1)
+	if err != nil {
+		t.Fatalf("HashZip(p1): %v", err)
+	}
+	sum2, err := HashZip(p2)
+	if err != nil {
+		t.Fatalf("HashZip(p2): %v", err)
+	}
+
+	if sum1 != sum2 {
+		t.Errorf("hashes of identical content differ: %v != %v", sum1, sum2)
+	}
+	if sum1[:3] != "h1:" {
+		t.Errorf("hash %q does not have the expected h1: prefix", sum1)
+	}
+}
+
+func TestHashZipDiffersOnContentChange(t *testing.T) {
+	dir := t.TempDir()
+
+	p1 := filepath.Join(dir, "one.zip")
+	p2 := filepath.Join(dir, "two.zip")
+	writeTestZip(t, p1, map[string]string{"a.txt": "first"})
+	writeTestZip(t, p2, map[string]string{"a.txt": "different"})
+
+	sum1, err := HashZip(p1)
+	if err != nil {
+		t.Fatalf("HashZip(p1): %v", err)
+	}
+	sum2, err := HashZip(p2)
+	if err != nil {
+		t.Fatalf("HashZip(p2): %v", err)
+	}
+
+	if sum1 == sum2 {
+		t.Errorf("expected different content to hash differently, both got %v", sum1)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hash
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/fission/fission/pkg/utils"
+)
+
+// HashTar computes the "h1:" content hash of the tar-family archive
+// (tar, tar.gz, tar.bz2, tar.xz or tar.zst) at path. Unlike HashZip, tar is a
+// streaming format with no central directory, so entries are read and
+// hashed in a single pass and then sorted before the outer hash is taken.
+func HashTar(path string, format utils.ArchiveFormat) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("opening archive: %w", err)
+	}
+	defer f.Close()
+
+	r, err := utils.TarDecompressor(f, format)
+	if err != nil {
+		return "", err
+	}
+
+	type entry struct {
+		name string
+		mode os.FileMode
+		sum  []byte
+	}
+	var entries []entry
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading tar entry: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		h := sha256.New()
+		if _, err := io.Copy(h, tr); err != nil {
+			return "", fmt.Errorf("hashing entry %q: %w", hdr.Name, err)
+		}
+		entries = append(entries, entry{hdr.Name, hdr.FileInfo().Mode().Perm(), h.Sum(nil)})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+
+	h := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(h, "%x %o  %s\n", e.sum, e.mode, e.name)
+	}
+
+	return encode(h.Sum(nil)), nil
+}
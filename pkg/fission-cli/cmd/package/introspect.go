@@ -0,0 +1,115 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package _package
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/fission.io/v1"
+	"github.com/fission/fission/pkg/controller/client"
+	pkgutil "github.com/fission/fission/pkg/fission-cli/cmd/package/util"
+)
+
+// ArchiveFileInfo describes one entry of an archive, as printed by
+// `fission package files`.
+type ArchiveFileInfo struct {
+	Path string
+	Size int64
+	Mode fs.FileMode
+}
+
+// ListArchiveFiles walks ar's contents without extracting it, for
+// `fission package files <name>`.
+func ListArchiveFiles(ctx context.Context, c *client.Client, ar *fv1.Archive) ([]ArchiveFileInfo, error) {
+	archiveFS, err := pkgutil.OpenArchive(ctx, c, ar)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening archive")
+	}
+
+	var entries []ArchiveFileInfo
+	err = fs.WalkDir(archiveFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, ArchiveFileInfo{Path: path, Size: info.Size(), Mode: info.Mode()})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "walking archive")
+	}
+
+	return entries, nil
+}
+
+// CatArchiveFile returns the contents of path within ar, for
+// `fission package cat <name> <path>`.
+func CatArchiveFile(ctx context.Context, c *client.Client, ar *fv1.Archive, path string) ([]byte, error) {
+	archiveFS, err := pkgutil.OpenArchive(ctx, c, ar)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening archive")
+	}
+
+	f, err := archiveFS.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %q in archive", path)
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// VerifyArchive recomputes ar's content checksum and compares it against
+// the one stored on the Archive object, for `fission package verify <name>`.
+func VerifyArchive(ctx context.Context, c *client.Client, ar *fv1.Archive) error {
+	if ar.Checksum.Sum == "" {
+		return fmt.Errorf("archive has no stored checksum to verify against")
+	}
+
+	// VerifyArchive re-derives the checksum from the archive's declared
+	// URL, the same way CreateArchive computes one for a freshly built
+	// archive, so it has to download the archive rather than stream
+	// through OpenArchive.
+	tmpFile, err := pkgutil.DownloadArchive(ctx, c, ar.URL)
+	if err != nil {
+		return errors.Wrap(err, "downloading archive")
+	}
+	defer os.Remove(tmpFile)
+
+	got, err := pkgutil.ChecksumArchive(tmpFile)
+	if err != nil {
+		return errors.Wrap(err, "computing archive checksum")
+	}
+
+	if got.Sum != ar.Checksum.Sum {
+		return fmt.Errorf("checksum mismatch: stored %v, computed %v", ar.Checksum.Sum, got.Sum)
+	}
+
+	return nil
+}
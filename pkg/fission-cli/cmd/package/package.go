@@ -25,11 +25,11 @@ import (
 
 	"github.com/dchest/uniuri"
 	"github.com/hashicorp/go-multierror"
-	"github.com/mholt/archiver"
 	"github.com/pkg/errors"
 
 	fv1 "github.com/fission/fission/pkg/apis/fission.io/v1"
 	"github.com/fission/fission/pkg/controller/client"
+	"github.com/fission/fission/pkg/fission-cli/cmd/package/fetch"
 	pkgutil "github.com/fission/fission/pkg/fission-cli/cmd/package/util"
 	"github.com/fission/fission/pkg/fission-cli/cmd/spec"
 	spectypes "github.com/fission/fission/pkg/fission-cli/cmd/spec/types"
@@ -41,7 +41,17 @@ import (
 // create an archive upload spec in the specs directory; otherwise
 // upload the archive using client.  noZip avoids zipping the
 // includeFiles, but is ignored if there's more than one includeFile.
-func CreateArchive(client *client.Client, includeFiles []string, noZip bool, specDir string, specFile string) (*fv1.Archive, error) {
+// archiveFormat picks the output format (zip, tar, tar.gz, tar.bz2, tar.xz or
+// tar.zst) when Fission has to build the archive itself; it's ignored when
+// includeFiles already resolves to a single existing archive or URL.
+// parallelUploads caps how many chunks of the archive are uploaded to the
+// controller concurrently; 0 uses pkgutil.DefaultParallelUploads.
+func CreateArchive(client *client.Client, includeFiles []string, noZip bool, archiveFormat utils.ArchiveFormat, parallelUploads int, specDir string, specFile string) (*fv1.Archive, error) {
+
+	includeFiles, err := resolveRemoteSources(includeFiles)
+	if err != nil {
+		return nil, err
+	}
 
 	errs := &multierror.Error{}
 
@@ -67,11 +77,39 @@ func CreateArchive(client *client.Client, includeFiles []string, noZip bool, spe
 		return nil, errs.ErrorOrNil()
 	}
 
+	archivePath := makeArchiveFileIfNeeded("", includeFiles, noZip, archiveFormat)
+
+	// Archives that are just a reference to an HTTP URL have no local file
+	// to inspect; everything else is validated and hashed so it can be
+	// named and deduplicated by content.
+	var checksum fv1.Checksum
+	if !strings.HasPrefix(archivePath, "http://") && !strings.HasPrefix(archivePath, "https://") {
+		detected, _, err := utils.DetectArchiveFormat(archivePath)
+		util.CheckErr(err, "detect archive format")
+
+		if err := utils.ValidateArchiveSafety(archivePath, detected, utils.DefaultArchiveLimits); err != nil {
+			if merr, ok := err.(*multierror.Error); ok {
+				errs = multierror.Append(errs, merr.Errors...)
+			} else {
+				errs = multierror.Append(errs, err)
+			}
+		}
+
+		if errs.ErrorOrNil() != nil {
+			return nil, errs.ErrorOrNil()
+		}
+
+		checksum, err = pkgutil.ChecksumArchive(archivePath)
+		util.CheckErr(err, "compute archive checksum")
+	}
+
 	if len(specFile) > 0 {
 		// create an ArchiveUploadSpec and reference it from the archive
 		aus := &spectypes.ArchiveUploadSpec{
-			Name:         archiveName("", includeFiles),
+			Name:         contentAddressedName(checksum, includeFiles),
 			IncludeGlobs: includeFiles,
+			Checksum:     checksum,
+			Format:       string(archiveFormat),
 		}
 
 		// check if this AUS exists in the specs; if so, don't create a new one
@@ -88,16 +126,31 @@ func CreateArchive(client *client.Client, includeFiles []string, noZip bool, spe
 
 		// create the archive object
 		ar := &fv1.Archive{
-			Type: fv1.ArchiveTypeUrl,
-			URL:  fmt.Sprintf("%v%v", spec.ARCHIVE_URL_PREFIX, aus.Name),
+			Type:     fv1.ArchiveTypeUrl,
+			URL:      fmt.Sprintf("%v%v", spec.ARCHIVE_URL_PREFIX, aus.Name),
+			Checksum: aus.Checksum,
 		}
 		return ar, nil
 	}
 
-	archivePath := makeArchiveFileIfNeeded("", includeFiles, noZip)
+	// archivePath is left as a plain HTTP(S) URL above when includeFiles
+	// resolves to one; there's no local file to upload in that case, so
+	// just reference the URL directly instead of handing it to
+	// UploadArchive, which expects a path it can open on disk.
+	if strings.HasPrefix(archivePath, "http://") || strings.HasPrefix(archivePath, "https://") {
+		return &fv1.Archive{
+			Type:     fv1.ArchiveTypeUrl,
+			URL:      archivePath,
+			Checksum: checksum,
+		}, nil
+	}
+
+	if parallelUploads < 1 {
+		parallelUploads = pkgutil.DefaultParallelUploads
+	}
 
 	ctx := context.Background()
-	return pkgutil.UploadArchive(ctx, client, archivePath)
+	return pkgutil.UploadArchive(ctx, client, archivePath, parallelUploads)
 }
 
 // Create an archive from the given list of input files, unless that
@@ -107,7 +160,10 @@ func CreateArchive(client *client.Client, includeFiles []string, noZip bool, spe
 // returned as-is with no zipping.  (This is used for compatibility
 // with v1 envs.)  noZip is IGNORED if there is more than one input
 // file.
-func makeArchiveFileIfNeeded(archiveNameHint string, archiveInput []string, noZip bool) string {
+//
+// format controls what kind of archive is produced when one has to be
+// built; an empty format defaults to zip.
+func makeArchiveFileIfNeeded(archiveNameHint string, archiveInput []string, noZip bool, format utils.ArchiveFormat) string {
 
 	// Unique name for the archive
 	archiveName := archiveName(archiveNameHint, archiveInput)
@@ -118,15 +174,17 @@ func makeArchiveFileIfNeeded(archiveNameHint string, archiveInput []string, noZi
 		util.CheckErr(err, "finding all globs")
 	}
 
-	// We have one file; if it's a zip file or a URL, no need to archive it
+	// We have one file; if it's already an archive of some known format
+	// (detected from its magic bytes, not its extension) or a URL, no need
+	// to archive it again.
 	if len(files) == 1 {
 		// make sure it exists
 		if _, err := os.Stat(files[0]); err != nil {
 			util.CheckErr(err, fmt.Sprintf("open input file %v", files[0]))
 		}
 
-		// if it's an existing zip file OR we're not supposed to zip it, don't do anything
-		if archiver.Zip.Match(files[0]) || noZip {
+		// if it's an existing archive OR we're not supposed to zip it, don't do anything
+		if _, ok, err := utils.DetectArchiveFormat(files[0]); err == nil && ok || noZip {
 			return files[0]
 		}
 
@@ -142,7 +200,7 @@ func makeArchiveFileIfNeeded(archiveNameHint string, archiveInput []string, noZi
 		util.CheckErr(err, "create temporary archive directory")
 	}
 
-	archivePath, err := utils.MakeArchive(filepath.Join(tmpDir, archiveName), archiveInput...)
+	archivePath, err := utils.MakeArchive(filepath.Join(tmpDir, archiveName), format, archiveInput...)
 	if err != nil {
 		util.CheckErr(err, "create archive file")
 	}
@@ -150,6 +208,26 @@ func makeArchiveFileIfNeeded(archiveNameHint string, archiveInput []string, noZi
 	return archivePath
 }
 
+// resolveRemoteSources replaces any go-getter-style entries (e.g.
+// "git::https://github.com/org/repo//subdir?ref=v1.2.3") in includeFiles
+// with the local directory each was fetched into, leaving plain paths and
+// plain http(s) URLs untouched.
+func resolveRemoteSources(includeFiles []string) ([]string, error) {
+	resolved := make([]string, len(includeFiles))
+	for i, f := range includeFiles {
+		if !fetch.IsRemoteSource(f) {
+			resolved[i] = f
+			continue
+		}
+		dir, err := fetch.Fetch(context.Background(), f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching %q", f)
+		}
+		resolved[i] = dir
+	}
+	return resolved, nil
+}
+
 // Name an archive
 func archiveName(givenNameHint string, includedFiles []string) string {
 	if len(givenNameHint) > 0 {
@@ -161,6 +239,17 @@ func archiveName(givenNameHint string, includedFiles []string) string {
 	return fmt.Sprintf("%v-%v", util.KubifyName(includedFiles[0]), uniuri.NewLen(4))
 }
 
+// contentAddressedName derives a deterministic name for an uploaded archive
+// from its checksum, so that two archives built from identical contents get
+// the same name instead of a fresh random suffix every time.
+func contentAddressedName(checksum fv1.Checksum, includedFiles []string) string {
+	sum := pkgutil.SanitizeChecksum(checksum.Sum)
+	if len(includedFiles) == 0 {
+		return sum
+	}
+	return fmt.Sprintf("%v-%v", util.KubifyName(includedFiles[0]), sum)
+}
+
 func GetFunctionsByPackage(client *client.Client, pkgName, pkgNamespace string) ([]fv1.Function, error) {
 	fnList, err := client.FunctionList(pkgNamespace)
 	if err != nil {
@@ -0,0 +1,246 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	fv1 "github.com/fission/fission/pkg/apis/fission.io/v1"
+	"github.com/fission/fission/pkg/controller/client"
+)
+
+// chunkSize is the size of each piece an archive is split into before
+// upload. 8 MiB keeps individual requests small enough to retry cheaply on
+// a flaky connection without re-sending the whole archive.
+const chunkSize = 8 * 1024 * 1024
+
+// resumeManifest records which chunks of an upload have already completed,
+// so that re-running `fission package create` after an interrupted upload
+// only sends what's missing.
+type resumeManifest struct {
+	ID          string `json:"id"`
+	TotalChunks int    `json:"totalChunks"`
+	Completed   []int  `json:"completed"`
+}
+
+// UploadArchiveChunked uploads the archive at archivePath to the
+// controller in chunkSize pieces, each carrying its own sha256, and then
+// finalizes the upload with checksum, the archive's "h1:" content checksum.
+// No separate sha256 of the whole archive file is computed or sent. Up
+// to parallelUploads chunks are sent concurrently. A resume manifest at
+// ~/.fission/uploads/{id}.json is updated as chunks complete, so an
+// interrupted upload can pick up where it left off on the next call.
+func UploadArchiveChunked(ctx context.Context, c *client.Client, archivePath string, checksum fv1.Checksum, parallelUploads int) (*fv1.Archive, error) {
+	if parallelUploads < 1 {
+		parallelUploads = 1
+	}
+	// checksum.Sum is an "h1:"-prefixed base64 string, which contains "/"
+	// and "+"/"=" characters that aren't safe in a file name or a
+	// "/v2/archives/{id}/..." path segment; sanitize it the same way
+	// CreateArchive does when turning a checksum into an archive name.
+	id := SanitizeChecksum(checksum.Sum)
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening archive %q", archivePath)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err, "stat archive")
+	}
+	totalChunks := int((info.Size() + chunkSize - 1) / chunkSize)
+
+	manifest, err := loadManifest(id)
+	if err != nil {
+		return nil, err
+	}
+	manifest.ID = id
+	manifest.TotalChunks = totalChunks
+
+	done := map[int]bool{}
+	for _, n := range manifest.Completed {
+		done[n] = true
+	}
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, parallelUploads)
+
+	for n := 0; n < totalChunks; n++ {
+		n := n
+		if done[n] {
+			continue
+		}
+
+		sem <- struct{}{}
+		g.Go(func() error {
+			defer func() { <-sem }()
+
+			data, sum, err := readChunk(archivePath, n)
+			if err != nil {
+				return err
+			}
+
+			if err := c.ArchiveUploadChunk(gctx, id, n, sum, data); err != nil {
+				return errors.Wrapf(err, "uploading chunk %d", n)
+			}
+
+			mu.Lock()
+			manifest.Completed = append(manifest.Completed, n)
+			err = saveManifest(manifest)
+			mu.Unlock()
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	url, err := c.ArchiveFinalize(ctx, id, checksum)
+	if err != nil {
+		return nil, errors.Wrap(err, "finalizing chunked upload")
+	}
+
+	if err := removeManifest(id); err != nil {
+		return nil, err
+	}
+
+	return &fv1.Archive{
+		Type:     fv1.ArchiveTypeUrl,
+		URL:      url,
+		Checksum: checksum,
+	}, nil
+}
+
+func readChunk(archivePath string, n int) (io.Reader, string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, chunkSize)
+	read, err := f.ReadAt(buf, int64(n)*chunkSize)
+	if err != nil && err != io.EOF {
+		return nil, "", errors.Wrapf(err, "reading chunk %d", n)
+	}
+	buf = buf[:read]
+
+	h := sha256.Sum256(buf)
+	return bytesReader(buf), fmt.Sprintf("%x", h), nil
+}
+
+func bytesReader(b []byte) io.Reader {
+	return &byteSliceReader{b: b}
+}
+
+// byteSliceReader avoids pulling in bytes.Reader just to satisfy io.Reader
+// for a one-shot chunk upload.
+type byteSliceReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *byteSliceReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.b) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.b[r.pos:])
+	r.pos += n
+	return n, nil
+}
+
+func uploadsDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "finding home directory")
+	}
+	return filepath.Join(home, ".fission", "uploads"), nil
+}
+
+func manifestPath(id string) (string, error) {
+	dir, err := uploadsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, id+".json"), nil
+}
+
+func loadManifest(id string) (*resumeManifest, error) {
+	path, err := manifestPath(id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &resumeManifest{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading resume manifest")
+	}
+
+	var m resumeManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, errors.Wrap(err, "parsing resume manifest")
+	}
+	return &m, nil
+}
+
+func saveManifest(m *resumeManifest) error {
+	path, err := manifestPath(m.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrap(err, "creating uploads directory")
+	}
+
+	sort.Ints(m.Completed)
+	data, err := json.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "encoding resume manifest")
+	}
+
+	return ioutil.WriteFile(path, data, 0o644)
+}
+
+func removeManifest(id string) error {
+	path, err := manifestPath(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing resume manifest")
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeChecksum(t *testing.T) {
+	sum := "h1:ab+c/de=="
+	got := SanitizeChecksum(sum)
+
+	if strings.ContainsAny(got, "+/=") || strings.HasPrefix(got, "h1:") {
+		t.Errorf("SanitizeChecksum(%q) = %q, still contains unsafe characters", sum, got)
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	id := SanitizeChecksum("h1:ab+c/de==")
+
+	m, err := loadManifest(id)
+	if err != nil {
+		t.Fatalf("loadManifest on a missing manifest: %v", err)
+	}
+	if len(m.Completed) != 0 {
+		t.Fatalf("expected an empty manifest, got %+v", m)
+	}
+
+	m.ID = id
+	m.TotalChunks = 3
+	m.Completed = []int{2, 0}
+	if err := saveManifest(m); err != nil {
+		t.Fatalf("saveManifest: %v", err)
+	}
+
+	loaded, err := loadManifest(id)
+	if err != nil {
+		t.Fatalf("loadManifest after save: %v", err)
+	}
+	if loaded.TotalChunks != 3 {
+		t.Errorf("TotalChunks = %d, want 3", loaded.TotalChunks)
+	}
+	if len(loaded.Completed) != 2 {
+		t.Errorf("Completed = %v, want 2 entries", loaded.Completed)
+	}
+
+	if err := removeManifest(id); err != nil {
+		t.Fatalf("removeManifest: %v", err)
+	}
+
+	afterRemove, err := loadManifest(id)
+	if err != nil {
+		t.Fatalf("loadManifest after remove: %v", err)
+	}
+	if len(afterRemove.Completed) != 0 {
+		t.Errorf("expected an empty manifest after removal, got %+v", afterRemove)
+	}
+}
+
+func TestManifestPathUsesSanitizedID(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	id := SanitizeChecksum("h1:ab+c/de==")
+	path, err := manifestPath(id)
+	if err != nil {
+		t.Fatalf("manifestPath: %v", err)
+	}
+	if !strings.HasSuffix(path, id+".json") {
+		t.Errorf("manifest path %q doesn't end in the sanitized id, got id %q", path, id)
+	}
+}
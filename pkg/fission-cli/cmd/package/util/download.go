@@ -0,0 +1,60 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/fission/fission/pkg/controller/client"
+)
+
+// DownloadArchive fetches the archive at url into a temp file and returns
+// its path, for callers (like `fission package verify`) that need the
+// whole archive on disk rather than a streaming fs.FS view of it. Requests
+// go through c's HTTP client, the same as OpenArchive, rather than
+// anonymously via http.DefaultClient.
+func DownloadArchive(ctx context.Context, c *client.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := httpClient(c).Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "downloading archive")
+	}
+	defer resp.Body.Close()
+
+	f, err := ioutil.TempFile("", "fission-archive-")
+	if err != nil {
+		return "", errors.Wrap(err, "creating temp file")
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		os.Remove(f.Name())
+		return "", errors.Wrap(err, "writing downloaded archive")
+	}
+
+	return f.Name(), nil
+}
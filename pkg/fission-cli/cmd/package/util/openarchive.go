@@ -0,0 +1,218 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"testing/fstest"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/fission.io/v1"
+	"github.com/fission/fission/pkg/controller/client"
+	"github.com/fission/fission/pkg/utils"
+)
+
+// OpenArchive returns a read-only fs.FS over ar's contents, streamed
+// directly from the controller's archive URL without ever extracting the
+// archive to disk. Zip archives are read with HTTP Range requests, so only
+// the entries actually opened get downloaded; tar-family archives have no
+// central directory to seek to, so they're read sequentially into memory
+// instead. Requests go through c's HTTP client so an authenticated archive
+// URL can actually be read, rather than anonymously via
+// http.DefaultClient.
+func OpenArchive(ctx context.Context, c *client.Client, ar *fv1.Archive) (fs.FS, error) {
+	if ar.Type != fv1.ArchiveTypeUrl {
+		return nil, fmt.Errorf("can't open an archive of type %q without extracting it", ar.Type)
+	}
+
+	header, err := rangeGet(ctx, c, ar.URL, 0, 511)
+	if err != nil {
+		return nil, errors.Wrap(err, "probing archive format")
+	}
+	format, ok := utils.SniffArchiveFormat(header)
+	if !ok {
+		format = utils.ArchiveFormatZip
+	}
+
+	if format == utils.ArchiveFormatZip {
+		return openZipArchive(c, ar.URL)
+	}
+	return openTarArchive(ctx, c, ar.URL, format)
+}
+
+func openZipArchive(c *client.Client, url string) (fs.FS, error) {
+	size, err := contentLength(c, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting archive size")
+	}
+
+	r, err := zip.NewReader(&httpRangeReaderAt{client: c, url: url}, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading zip directory")
+	}
+
+	return r, nil
+}
+
+func openTarArchive(ctx context.Context, c *client.Client, url string, format utils.ArchiveFormat) (fs.FS, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := httpClient(c).Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "downloading archive")
+	}
+	defer httpResp.Body.Close()
+
+	body, err := utils.TarDecompressor(httpResp.Body, format)
+	if err != nil {
+		return nil, err
+	}
+
+	out := fstest.MapFS{}
+	tr := tar.NewReader(body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading tar entry")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data := make([]byte, hdr.Size)
+		if _, err := io.ReadFull(tr, data); err != nil {
+			return nil, errors.Wrapf(err, "reading entry %q", hdr.Name)
+		}
+		out[hdr.Name] = &fstest.MapFile{Data: data, Mode: hdr.FileInfo().Mode()}
+	}
+
+	return out, nil
+}
+
+// httpRangeReaderAt reads from an HTTP resource using Range requests, so
+// archive/zip.NewReader can seek through the archive's central directory
+// without downloading the whole thing.
+type httpRangeReaderAt struct {
+	client *client.Client
+	url    string
+}
+
+func (r *httpRangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	data, err := rangeGet(context.Background(), r.client, r.url, off, off+int64(len(p))-1)
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, data)
+	if n < len(p) {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, nil
+}
+
+// rangeGet fetches the byte range [start, end] of url and returns exactly
+// that window. It insists the server actually honored the Range request: a
+// 206 response's Content-Range start must match what was asked for, and a
+// 200 response (meaning the server ignored Range and returned the whole
+// body) is only accepted for start == 0, since any other offset would
+// otherwise silently read from the wrong place.
+func rangeGet(ctx context.Context, c *client.Client, url string, start, end int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := httpClient(c).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		gotStart, err := parseContentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return nil, fmt.Errorf("fetching %v: %w", url, err)
+		}
+		if gotStart != start {
+			return nil, fmt.Errorf("fetching %v: requested range starting at %d, server returned range starting at %d", url, start, gotStart)
+		}
+		return data, nil
+	case http.StatusOK:
+		if start != 0 {
+			return nil, fmt.Errorf("fetching %v: server ignored the Range request for a non-zero offset; archive introspection requires Range support", url)
+		}
+		if want := end - start + 1; int64(len(data)) > want {
+			data = data[:want]
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unexpected status %v fetching %v", resp.Status, url)
+	}
+}
+
+// parseContentRangeStart parses the start offset out of a "Content-Range:
+// bytes <start>-<end>/<total>" header value.
+func parseContentRangeStart(header string) (int64, error) {
+	var start, end, total int64
+	if _, err := fmt.Sscanf(header, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return 0, fmt.Errorf("parsing Content-Range %q: %w", header, err)
+	}
+	return start, nil
+}
+
+// httpClient returns the *http.Client archive requests should use, so they
+// carry the same auth/transport as the rest of the controller client
+// instead of going out anonymously via http.DefaultClient.
+func httpClient(c *client.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient()
+}
+
+func contentLength(c *client.Client, url string) (int64, error) {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := httpClient(c).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+}
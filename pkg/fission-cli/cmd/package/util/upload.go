@@ -0,0 +1,88 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package util holds helpers shared by the fission-cli package subcommands
+// that need to talk to the controller's archive storage.
+package util
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	fv1 "github.com/fission/fission/pkg/apis/fission.io/v1"
+	"github.com/fission/fission/pkg/controller/client"
+	"github.com/fission/fission/pkg/fission-cli/cmd/package/hash"
+	"github.com/fission/fission/pkg/utils"
+)
+
+// DefaultParallelUploads is used when a caller doesn't set its own
+// --parallel-uploads value.
+const DefaultParallelUploads = 4
+
+// UploadArchive uploads the archive at archivePath to the controller and
+// returns the resulting fv1.Archive. The archive's content checksum is
+// computed first; if the controller already has a package with the same
+// checksum, that archive is reused and the upload is skipped. Otherwise the
+// archive is streamed up in chunkSize pieces, up to parallelUploads of them
+// concurrently, resuming from ~/.fission/uploads/{id}.json if a previous
+// attempt was interrupted partway through.
+func UploadArchive(ctx context.Context, c *client.Client, archivePath string, parallelUploads int) (*fv1.Archive, error) {
+	checksum, err := ChecksumArchive(archivePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "computing archive checksum")
+	}
+
+	if existing, err := c.ArchiveGetByChecksum(ctx, checksum); err == nil && existing != nil {
+		return existing, nil
+	}
+
+	return UploadArchiveChunked(ctx, c, archivePath, checksum, parallelUploads)
+}
+
+// ChecksumArchive computes the fv1.Checksum of the archive at archivePath,
+// detecting its format from its magic bytes rather than its extension. A
+// file with no recognised magic bytes is hashed as a zip.
+func ChecksumArchive(archivePath string) (fv1.Checksum, error) {
+	format, ok, err := utils.DetectArchiveFormat(archivePath)
+	if err != nil {
+		return fv1.Checksum{}, err
+	}
+	if !ok {
+		format = utils.ArchiveFormatZip
+	}
+
+	var sum string
+	if format == utils.ArchiveFormatZip {
+		sum, err = hash.HashZip(archivePath)
+	} else {
+		sum, err = hash.HashTar(archivePath, format)
+	}
+	if err != nil {
+		return fv1.Checksum{}, err
+	}
+
+	return fv1.Checksum{Type: fv1.ChecksumTypeSHA256, Sum: sum}, nil
+}
+
+// SanitizeChecksum strips the parts of an "h1:" checksum that are unsafe to
+// use verbatim in a file name or URL path segment: the "h1:" prefix, and
+// base64's "+", "/" and "=". Used wherever a checksum is turned into a name
+// or id rather than just compared for equality.
+func SanitizeChecksum(sum string) string {
+	return strings.NewReplacer("h1:", "", "+", "-", "/", "_", "=", "").Replace(sum)
+}
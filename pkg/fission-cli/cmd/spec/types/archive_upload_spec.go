@@ -0,0 +1,38 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	fv1 "github.com/fission/fission/pkg/apis/fission.io/v1"
+)
+
+// ArchiveUploadSpec describes a set of local files that should be archived
+// and uploaded when `fission spec apply` runs. It's referenced from a
+// fv1.Archive's URL via the "archive://" spec prefix.
+type ArchiveUploadSpec struct {
+	Name         string   `json:"name"`
+	IncludeGlobs []string `json:"includeglobs,omitempty"`
+	RootDir      string   `json:"rootdir,omitempty"`
+
+	// Checksum of the archive this spec produces, used to detect when an
+	// identical archive already exists so re-upload can be skipped.
+	Checksum fv1.Checksum `json:"checksum,omitempty"`
+
+	// Format is the archive format (zip, tar, tar.gz, tar.bz2, tar.xz or
+	// tar.zst) this spec's archive was/should be built with. Empty means zip.
+	Format string `json:"format,omitempty"`
+}
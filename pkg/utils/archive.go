@@ -0,0 +1,203 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mholt/archiver"
+)
+
+// ArchiveFormat identifies one of the archive formats Fission knows how to
+// produce and consume.
+type ArchiveFormat string
+
+const (
+	ArchiveFormatZip    ArchiveFormat = "zip"
+	ArchiveFormatTar    ArchiveFormat = "tar"
+	ArchiveFormatTarGz  ArchiveFormat = "tar.gz"
+	ArchiveFormatTarBz2 ArchiveFormat = "tar.bz2"
+	ArchiveFormatTarXz  ArchiveFormat = "tar.xz"
+	ArchiveFormatTarZst ArchiveFormat = "tar.zst"
+)
+
+// magic byte sequences used to detect a format regardless of file extension,
+// the same approach go-getter's decompressors use.
+var archiveMagic = []struct {
+	format ArchiveFormat
+	magic  []byte
+}{
+	{ArchiveFormatZip, []byte("PK\x03\x04")},
+	{ArchiveFormatTarGz, []byte{0x1f, 0x8b}},
+	{ArchiveFormatTarBz2, []byte("BZh")},
+	{ArchiveFormatTarXz, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{ArchiveFormatTarZst, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// DetectArchiveFormat sniffs the format of the archive at path from its
+// magic bytes rather than trusting its extension. Returns ok=false if path
+// isn't a recognized archive at all.
+func DetectArchiveFormat(path string) (format ArchiveFormat, ok bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 512)
+	n, err := f.Read(header)
+	if err != nil && n == 0 {
+		return "", false, nil
+	}
+
+	format, ok = SniffArchiveFormat(header[:n])
+	return format, ok, nil
+}
+
+// SniffArchiveFormat identifies the archive format header's magic bytes
+// belong to. A plain, uncompressed tar has no magic at offset 0, so it's
+// recognized by the "ustar" magic at offset 257 instead; header must be at
+// least 265 bytes for that case to be detected.
+func SniffArchiveFormat(header []byte) (format ArchiveFormat, ok bool) {
+	for _, m := range archiveMagic {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.format, true
+		}
+	}
+
+	if len(header) >= 265 && string(header[257:262]) == "ustar" {
+		return ArchiveFormatTar, true
+	}
+
+	return "", false
+}
+
+// MakeArchive creates an archive of the given format at dest (dest should
+// not include the format's extension; it's appended here) from sources,
+// which may be a mix of files and directories. It returns the path to the
+// created archive.
+func MakeArchive(dest string, format ArchiveFormat, sources ...string) (string, error) {
+	if format == "" {
+		format = ArchiveFormatZip
+	}
+
+	dest = fmt.Sprintf("%v.%v", dest, format)
+
+	var err error
+	switch format {
+	case ArchiveFormatZip:
+		err = archiver.Zip.Make(dest, sources)
+	case ArchiveFormatTar:
+		err = archiver.Tar.Make(dest, sources)
+	case ArchiveFormatTarGz:
+		err = archiver.TarGz.Make(dest, sources)
+	case ArchiveFormatTarBz2:
+		err = archiver.TarBz2.Make(dest, sources)
+	case ArchiveFormatTarXz:
+		err = archiver.TarXZ.Make(dest, sources)
+	case ArchiveFormatTarZst:
+		err = makeTarZst(dest, sources)
+	default:
+		return "", fmt.Errorf("unsupported archive format %q", format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("making %v archive: %w", format, err)
+	}
+
+	return dest, nil
+}
+
+// makeTarZst is split out because, unlike the other formats above,
+// mholt/archiver has no built-in zstd support, so the tar stream is built by
+// hand here and piped through klauspost/compress/zstd instead.
+func makeTarZst(dest string, sources []string) error {
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw, err := zstd.NewWriter(f)
+	if err != nil {
+		return err
+	}
+	defer zw.Close()
+
+	tw := tar.NewWriter(zw)
+	defer tw.Close()
+
+	for _, src := range sources {
+		if err := addToTar(tw, src); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addToTar walks src, adding it to tw as a single entry if it's a file or
+// as a directory tree rooted at its base name otherwise.
+func addToTar(tw *tar.Writer, src string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return addFileToTar(tw, src, filepath.Base(src), info)
+	}
+
+	parent := filepath.Dir(src)
+	return filepath.Walk(src, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(parent, p)
+		if err != nil {
+			return err
+		}
+		return addFileToTar(tw, p, filepath.ToSlash(rel), fi)
+	})
+}
+
+func addFileToTar(tw *tar.Writer, path, name string, info os.FileInfo) error {
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// ArchiveLimits bounds what CreateArchive and the environment fetchers will
+// accept, borrowing the restrictions golang.org/x/mod/zip enforces on module
+// zips.
+type ArchiveLimits struct {
+	// MaxUncompressedBytes caps the sum of all entries' uncompressed sizes.
+	MaxUncompressedBytes int64
+	// MaxFiles caps the number of entries in the archive.
+	MaxFiles int
+}
+
+// DefaultArchiveLimits is used wherever a caller doesn't configure its own.
+var DefaultArchiveLimits = ArchiveLimits{
+	MaxUncompressedBytes: 256 * 1024 * 1024, // 256 MiB
+	MaxFiles:             100000,
+}
+
+var windowsDriveLetter = regexp.MustCompile(`^[a-zA-Z]:`)
+
+// ValidateArchiveSafety opens the archive at path and checks every entry
+// against limits: total uncompressed size, entry count, path traversal
+// (".." components, absolute paths, Windows drive letters), symlinks that
+// point outside the archive root, and entries that only differ by case (and
+// would therefore collide on a case-insensitive filesystem). Every
+// violation found is collected rather than stopping at the first, so
+// callers can report them all at once.
+func ValidateArchiveSafety(archivePath string, format ArchiveFormat, limits ArchiveLimits) error {
+	switch format {
+	case ArchiveFormatZip, "":
+		return validateZipSafety(archivePath, limits)
+	case ArchiveFormatTar, ArchiveFormatTarGz, ArchiveFormatTarBz2, ArchiveFormatTarXz, ArchiveFormatTarZst:
+		return validateTarSafety(archivePath, format, limits)
+	default:
+		// Fail closed: an archive format this package doesn't know how to
+		// stream can't be vetted, so it must not be let through unchecked.
+		return fmt.Errorf("can't validate archive safety: unsupported format %q", format)
+	}
+}
+
+func validateZipSafety(archivePath string, limits ArchiveLimits) error {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("opening zip archive: %w", err)
+	}
+	defer r.Close()
+
+	errs := &multierror.Error{}
+	seen := map[string]string{}
+	var total int64
+
+	if len(r.File) > limits.MaxFiles {
+		errs = multierror.Append(errs, fmt.Errorf("archive has %d entries, exceeding the limit of %d", len(r.File), limits.MaxFiles))
+	}
+
+	for _, zf := range r.File {
+		if err := validateEntryName(zf.Name); err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		if zf.Mode()&os.ModeSymlink != 0 {
+			if err := validateSymlinkTarget(zf, limits); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+		if err := checkCaseCollision(seen, zf.Name); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+
+		total += int64(zf.UncompressedSize64)
+	}
+
+	if total > limits.MaxUncompressedBytes {
+		errs = multierror.Append(errs, fmt.Errorf("archive's uncompressed size %d exceeds the limit of %d bytes", total, limits.MaxUncompressedBytes))
+	}
+
+	return errs.ErrorOrNil()
+}
+
+func validateSymlinkTarget(zf *zip.File, limits ArchiveLimits) error {
+	rc, err := zf.Open()
+	if err != nil {
+		return fmt.Errorf("opening symlink entry %q: %w", zf.Name, err)
+	}
+	defer rc.Close()
+
+	// zf.UncompressedSize64 comes from the zip's central directory and is
+	// attacker-controlled; it need not match what's actually stored, so it
+	// must never size an allocation before anything has been read. Cap the
+	// read instead of trusting the declared size.
+	data, err := io.ReadAll(io.LimitReader(rc, limits.MaxUncompressedBytes+1))
+	if err != nil {
+		return fmt.Errorf("reading symlink target for %q: %w", zf.Name, err)
+	}
+	if int64(len(data)) > limits.MaxUncompressedBytes {
+		return fmt.Errorf("symlink target for %q exceeds the limit of %d bytes", zf.Name, limits.MaxUncompressedBytes)
+	}
+
+	return validateLinkTarget(zf.Name, string(data))
+}
+
+func validateTarSafety(archivePath string, format ArchiveFormat, limits ArchiveLimits) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+	defer f.Close()
+
+	r, err := TarDecompressor(f, format)
+	if err != nil {
+		return err
+	}
+
+	errs := &multierror.Error{}
+	seen := map[string]string{}
+	var total int64
+	var count int
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("reading tar entry: %w", err))
+			break
+		}
+		count++
+
+		if err := validateEntryName(hdr.Name); err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		if hdr.Typeflag == tar.TypeSymlink {
+			if err := validateLinkTarget(hdr.Name, hdr.Linkname); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+		}
+		if err := checkCaseCollision(seen, hdr.Name); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+
+		total += hdr.Size
+	}
+
+	if count > limits.MaxFiles {
+		errs = multierror.Append(errs, fmt.Errorf("archive has %d entries, exceeding the limit of %d", count, limits.MaxFiles))
+	}
+	if total > limits.MaxUncompressedBytes {
+		errs = multierror.Append(errs, fmt.Errorf("archive's uncompressed size %d exceeds the limit of %d bytes", total, limits.MaxUncompressedBytes))
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// validateEntryName rejects names that could escape the archive root once
+// extracted: absolute paths, Windows drive letters, and ".." components.
+func validateEntryName(name string) error {
+	clean := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if path.IsAbs(clean) || windowsDriveLetter.MatchString(clean) {
+		return fmt.Errorf("entry %q has an absolute path", name)
+	}
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("entry %q escapes the archive root", name)
+	}
+	return nil
+}
+
+// validateLinkTarget rejects symlinks whose target, resolved relative to
+// the entry's directory, would land outside the archive root.
+func validateLinkTarget(name, target string) error {
+	if target == "" {
+		return nil
+	}
+	if path.IsAbs(target) || windowsDriveLetter.MatchString(target) {
+		return fmt.Errorf("symlink %q points to an absolute path %q", name, target)
+	}
+	resolved := path.Clean(path.Join(path.Dir(name), target))
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return fmt.Errorf("symlink %q points outside the archive root: %q", name, target)
+	}
+	return nil
+}
+
+// TarDecompressor wraps r with the decompressor matching format, or returns
+// r unchanged for a plain, uncompressed tar. Shared by anything that needs
+// to stream a tar-family archive's entries: safety validation here, and
+// content hashing in the package/hash subpackage.
+func TarDecompressor(r io.Reader, format ArchiveFormat) (io.Reader, error) {
+	switch format {
+	case ArchiveFormatTar:
+		return r, nil
+	case ArchiveFormatTarGz:
+		return gzip.NewReader(r)
+	case ArchiveFormatTarBz2:
+		return bzip2.NewReader(r), nil
+	case ArchiveFormatTarXz:
+		return xz.NewReader(r)
+	case ArchiveFormatTarZst:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported tar format %q", format)
+	}
+}
+
+// checkCaseCollision records name (case-folded) in seen and errors if
+// another entry already claimed the same case-folded name, since that would
+// collide on a case-insensitive filesystem.
+func checkCaseCollision(seen map[string]string, name string) error {
+	key := strings.ToLower(name)
+	if other, ok := seen[key]; ok && other != name {
+		return fmt.Errorf("entries %q and %q only differ by case", other, name)
+	}
+	seen[key] = name
+	return nil
+}
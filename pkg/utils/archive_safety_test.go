@@ -0,0 +1,117 @@
+/*
+Copyright 2019 The Fission Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeZipWithEntries(t *testing.T, path string, names []string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("creating %v: %v", path, err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("adding %v: %v", name, err)
+		}
+		if _, err := w.Write([]byte("data")); err != nil {
+			t.Fatalf("writing %v: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("closing zip: %v", err)
+	}
+}
+
+func TestValidateArchiveSafetyRejectsPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "evil.zip")
+	writeZipWithEntries(t, path, []string{"../../etc/passwd"})
+
+	if err := ValidateArchiveSafety(path, ArchiveFormatZip, DefaultArchiveLimits); err == nil {
+		t.Fatal("expected an error for a path-traversal entry, got nil")
+	}
+}
+
+func TestValidateArchiveSafetyRejectsAbsolutePath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "evil.zip")
+	writeZipWithEntries(t, path, []string{"/etc/passwd"})
+
+	if err := ValidateArchiveSafety(path, ArchiveFormatZip, DefaultArchiveLimits); err == nil {
+		t.Fatal("expected an error for an absolute path entry, got nil")
+	}
+}
+
+func TestValidateArchiveSafetyRejectsCaseCollision(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "evil.zip")
+	writeZipWithEntries(t, path, []string{"README.md", "readme.md"})
+
+	if err := ValidateArchiveSafety(path, ArchiveFormatZip, DefaultArchiveLimits); err == nil {
+		t.Fatal("expected an error for case-colliding entries, got nil")
+	}
+}
+
+func TestValidateArchiveSafetyAcceptsCleanArchive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ok.zip")
+	writeZipWithEntries(t, path, []string{"a.txt", "dir/b.txt"})
+
+	if err := ValidateArchiveSafety(path, ArchiveFormatZip, DefaultArchiveLimits); err != nil {
+		t.Fatalf("expected no error for a clean archive, got %v", err)
+	}
+}
+
+func TestValidateArchiveSafetyFailsClosedOnUnknownFormat(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ok.zip")
+	writeZipWithEntries(t, path, []string{"a.txt"})
+
+	if err := ValidateArchiveSafety(path, ArchiveFormat("unknown"), DefaultArchiveLimits); err == nil {
+		t.Fatal("expected an unsupported format to fail closed, got nil")
+	}
+}
+
+func TestValidateLinkTarget(t *testing.T) {
+	cases := []struct {
+		name    string
+		target  string
+		wantErr bool
+	}{
+		{"a/b", "../../etc/passwd", true},
+		{"a/b", "/etc/passwd", true},
+		{"a/b", "c", false},
+		{"a/b", "../c", false},
+	}
+	for _, c := range cases {
+		err := validateLinkTarget(c.name, c.target)
+		if (err != nil) != c.wantErr {
+			t.Errorf("validateLinkTarget(%q, %q) error = %v, wantErr %v", c.name, c.target, err, c.wantErr)
+		}
+	}
+}